@@ -0,0 +1,243 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheStats summarizes the configured Store's current contents, returned by
+// GET /admin/cache/stats.
+type CacheStats struct {
+	Size           int64         `json:"size"`
+	OldestEntryAge time.Duration `json:"oldestEntryAgeNanos"`
+}
+
+// StatsStore is implemented by Store backends that can report CacheStats.
+// For backends that don't implement it, AdminStatsHandler leaves the cache
+// section out of its response and still answers 200; unlike EventsHandler,
+// which answers 501 for a backend without WatchableStore.
+type StatsStore interface {
+	Store
+	Stats() CacheStats
+}
+
+// CacheEntry is one row of a GET /admin/cache/entries page.
+type CacheEntry struct {
+	InventoryID string    `json:"inventoryId"`
+	UtcDatetime string    `json:"utcDatetime"`
+	Emissions   float64   `json:"totalEmissions"`
+	Priority    uint8     `json:"priority"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// EnumerableStore is implemented by Store backends that can page through
+// their entries, used by GET /admin/cache/entries.
+type EnumerableStore interface {
+	Store
+	// Entries returns up to limit entries whose InventoryID starts with
+	// prefix, ordered and paginated after cursor (the InventoryID|UtcDatetime
+	// of the last entry seen, or "" for the first page). nextCursor is ""
+	// once there are no further pages.
+	Entries(prefix, cursor string, limit int) (entries []CacheEntry, nextCursor string)
+}
+
+// FlushableStore is implemented by Store backends that can drop every entry
+// at once, used by POST /admin/cache/flush.
+type FlushableStore interface {
+	Store
+	Flush()
+}
+
+// Stats reports the Cache's current size and the age of its oldest entry.
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := CacheStats{Size: c.currSize}
+	var oldest time.Time
+	for _, item := range c.items {
+		if oldest.IsZero() || item.timestamp.Before(oldest) {
+			oldest = item.timestamp
+		}
+	}
+	if !oldest.IsZero() {
+		stats.OldestEntryAge = time.Since(oldest)
+	}
+	return stats
+}
+
+// Entries implements EnumerableStore by sorting the cache's keys and paging
+// through them after cursor.
+func (c *Cache) Entries(prefix, cursor string, limit int) ([]CacheEntry, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []CacheKey
+	for key := range c.items {
+		if strings.HasPrefix(key.InventoryID, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return cacheEntrySortKey(keys[i]) < cacheEntrySortKey(keys[j])
+	})
+
+	start := 0
+	if cursor != "" {
+		start = sort.Search(len(keys), func(i int) bool {
+			return cacheEntrySortKey(keys[i]) > cursor
+		})
+	}
+
+	end := start + limit
+	if end > len(keys) {
+		end = len(keys)
+	}
+
+	entries := make([]CacheEntry, 0, end-start)
+	for _, key := range keys[start:end] {
+		item := c.items[key]
+		entries = append(entries, CacheEntry{
+			InventoryID: key.InventoryID,
+			UtcDatetime: key.UtcDatetime,
+			Emissions:   item.value.Emissions,
+			Priority:    item.value.Priority,
+			ExpiresAt:   item.expiry,
+		})
+	}
+
+	nextCursor := ""
+	if end < len(keys) {
+		nextCursor = cacheEntrySortKey(keys[end-1])
+	}
+	return entries, nextCursor
+}
+
+// Flush removes every entry from the cache, notifying Watch subscribers of
+// each eviction.
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	evicted := make([]*CacheItem, 0, len(c.items))
+	for _, item := range c.items {
+		evicted = append(evicted, item)
+	}
+	c.items = make(map[CacheKey]*CacheItem)
+	c.pq = make(PriorityQueue, 0)
+	c.currSize = 0
+	c.mu.Unlock()
+
+	for _, item := range evicted {
+		c.notify(CacheEvent{Type: CacheEventEvicted, Key: item.key, Value: item.value})
+	}
+}
+
+// cacheEntrySortKey renders key as the string used to order and paginate
+// Cache.Entries.
+func cacheEntrySortKey(key CacheKey) string {
+	return key.InventoryID + "|" + key.UtcDatetime
+}
+
+var (
+	_ StatsStore      = (*Cache)(nil)
+	_ EnumerableStore = (*Cache)(nil)
+	_ FlushableStore  = (*Cache)(nil)
+)
+
+// requireAdminToken returns a gin.HandlerFunc that rejects any request whose
+// X-Admin-Token header doesn't match the ADMIN_TOKEN environment variable.
+// If ADMIN_TOKEN is unset, the admin API is disabled entirely rather than
+// left open, since an empty expected token would otherwise match an absent
+// header.
+func requireAdminToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		want := os.Getenv("ADMIN_TOKEN")
+		if want == "" || c.GetHeader("X-Admin-Token") != want {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid X-Admin-Token"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminStatsHandler reports hit/miss counters and, when the configured Store
+// supports it, its size and oldest-entry age.
+func (s *Server) AdminStatsHandler(c *gin.Context) {
+	resp := gin.H{"metrics": s.Metrics.Snapshot()}
+	if stats, ok := s.Cache.(StatsStore); ok {
+		resp["cache"] = stats.Stats()
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AdminEntriesHandler returns a page of cache entries whose InventoryID
+// starts with the ?prefix= query parameter, continuing from ?cursor= and
+// bounded by ?limit= (default 100). Backends that don't support enumeration
+// report HTTP 501.
+func (s *Server) AdminEntriesHandler(c *gin.Context) {
+	enumerable, ok := s.Cache.(EnumerableStore)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "the configured cache backend does not support enumeration"})
+		return
+	}
+
+	limit := 100
+	if raw := c.Query("limit"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	entries, nextCursor := enumerable.Entries(c.Query("prefix"), c.Query("cursor"), limit)
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "nextCursor": nextCursor})
+}
+
+// AdminDeleteEntryHandler removes a single entry, keyed by its InventoryID
+// and UtcDatetime path parameters.
+func (s *Server) AdminDeleteEntryHandler(c *gin.Context) {
+	key := CacheKey{InventoryID: c.Param("inventoryId"), UtcDatetime: c.Param("date")}
+	s.Cache.Delete(key)
+	c.JSON(http.StatusOK, gin.H{"deleted": key})
+}
+
+// AdminFlushHandler drops every cache entry. Backends that don't support it
+// report HTTP 501.
+func (s *Server) AdminFlushHandler(c *gin.Context) {
+	flushable, ok := s.Cache.(FlushableStore)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "the configured cache backend does not support flushing"})
+		return
+	}
+	flushable.Flush()
+	c.JSON(http.StatusOK, gin.H{"flushed": true})
+}
+
+// AdminConfigHandler hot-reloads CacheExpiration from a JSON body
+// {"cacheExpirationSeconds": <n>} without restarting the process.
+func (s *Server) AdminConfigHandler(c *gin.Context) {
+	var body struct {
+		CacheExpirationSeconds *float64 `json:"cacheExpirationSeconds"`
+	}
+	if err := c.BindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if body.CacheExpirationSeconds == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cacheExpirationSeconds is required"})
+		return
+	}
+
+	s.SetCacheExpiration(time.Duration(*body.CacheExpirationSeconds * float64(time.Second)))
+	c.JSON(http.StatusOK, gin.H{"cacheExpiration": s.CacheExpiration()})
+}
+
+// MetricsHandler exposes operational counters in Prometheus text exposition
+// format.
+func (s *Server) MetricsHandler(c *gin.Context) {
+	c.String(http.StatusOK, s.Metrics.WriteProm(s.Cache.Len()))
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheEventType identifies what happened to a CacheKey.
+type CacheEventType string
+
+const (
+	CacheEventAdded    CacheEventType = "Added"
+	CacheEventUpdated  CacheEventType = "Updated"
+	CacheEventEvicted  CacheEventType = "Evicted"
+	CacheEventExpired  CacheEventType = "Expired"
+	CacheEventBookmark CacheEventType = "Bookmark"
+)
+
+// CacheEvent describes a single change to the Cache. A Bookmark carries no
+// Key or Value; it tells a subscriber that its ring buffer fell behind and
+// some events between the last one it saw and this one were dropped.
+type CacheEvent struct {
+	Type  CacheEventType `json:"type"`
+	Key   CacheKey       `json:"key,omitempty"`
+	Value CacheValue     `json:"value,omitempty"`
+}
+
+// subscriberBufferSize bounds how far a slow consumer can fall behind
+// before older events are dropped in favor of a Bookmark, so a stalled
+// subscriber cannot grow the Cache's memory use without limit.
+const subscriberBufferSize = 256
+
+// cacheSubscriber is one Watch caller's view of the event stream.
+type cacheSubscriber struct {
+	ch     chan CacheEvent
+	filter func(CacheKey) bool
+}
+
+// publish delivers event to the subscriber if it passes its filter. If the
+// subscriber's buffer is full, the two oldest queued events are dropped to
+// make room for a Bookmark followed by event, so the consumer knows to treat
+// its view as stale rather than silently missing data.
+func (s *cacheSubscriber) publish(event CacheEvent) {
+	if s.filter != nil && !s.filter(event.Key) {
+		return
+	}
+
+	select {
+	case s.ch <- event:
+		return
+	default:
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-s.ch:
+		default:
+		}
+	}
+	select {
+	case s.ch <- CacheEvent{Type: CacheEventBookmark}:
+	default:
+	}
+	select {
+	case s.ch <- event:
+	default:
+	}
+}
+
+// Watch streams Cache change events matching filter (or every event, when
+// filter is nil) until ctx is canceled. Modeled on Kubernetes' storage
+// cacher: multiple subscribers can watch concurrently, each with its own
+// bounded ring buffer, so a slow consumer cannot stall the others.
+func (c *Cache) Watch(ctx context.Context, filter func(CacheKey) bool) (<-chan CacheEvent, error) {
+	sub := &cacheSubscriber{
+		ch:     make(chan CacheEvent, subscriberBufferSize),
+		filter: filter,
+	}
+
+	c.subMu.Lock()
+	if c.subs == nil {
+		c.subs = make(map[*cacheSubscriber]struct{})
+	}
+	c.subs[sub] = struct{}{}
+	c.subMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.subMu.Lock()
+		delete(c.subs, sub)
+		c.subMu.Unlock()
+		close(sub.ch)
+	}()
+
+	return sub.ch, nil
+}
+
+// notify fans event out to every current subscriber.
+func (c *Cache) notify(event CacheEvent) {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	for sub := range c.subs {
+		sub.publish(event)
+	}
+}
+
+// EventsHandler streams Cache change events to the client over
+// Server-Sent Events until the client disconnects. Restricting the stream
+// to a single inventoryId is done with the ?inventoryId= query parameter,
+// so dashboards and cost-accounting systems can react to emission cache
+// changes without polling.
+func (s *Server) EventsHandler(c *gin.Context) {
+	watchable, ok := s.Cache.(WatchableStore)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "the configured cache backend does not support watching"})
+		return
+	}
+
+	var filter func(CacheKey) bool
+	if inventoryID := c.Query("inventoryId"); inventoryID != "" {
+		filter = func(key CacheKey) bool { return key.InventoryID == inventoryID }
+	}
+
+	events, err := watchable.Watch(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if !ok {
+			return false
+		}
+		c.SSEvent(string(event.Type), event)
+		return true
+	})
+}
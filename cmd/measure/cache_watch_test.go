@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestCacheWatchReceivesAddedAndEvictedEvents ensures a subscriber sees an
+// Added event on insert and an Evicted event once the cache's maxSize forces
+// an entry out. PriorityQueue.Less evicts the higher numeric Priority first
+// (see TestEmissionHandlerEvictionPriority), so the Priority: 2 entry is the
+// one that gets evicted here, not the Priority: 1 one.
+func TestCacheWatchReceivesAddedAndEvictedEvents(t *testing.T) {
+	cache := &Cache{
+		items:   make(map[CacheKey]*CacheItem),
+		maxSize: 1,
+		pq:      make(PriorityQueue, 0),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := cache.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from Watch: %v", err)
+	}
+
+	survivor := CacheKey{InventoryID: "nytimes.com", UtcDatetime: "2024-12-30"}
+	evictee := CacheKey{InventoryID: "yahoo.com", UtcDatetime: "2024-12-30"}
+
+	cache.SetWithTTL(survivor, CacheValue{Emissions: 1, Priority: 1}, time.Hour)
+	if event := mustReceive(t, events); event.Type != CacheEventAdded || event.Key != survivor {
+		t.Errorf("expected Added event for %v, got %+v", survivor, event)
+	}
+
+	cache.SetWithTTL(evictee, CacheValue{Emissions: 2, Priority: 2}, time.Hour)
+	if event := mustReceive(t, events); event.Type != CacheEventAdded || event.Key != evictee {
+		t.Errorf("expected Added event for %v, got %+v", evictee, event)
+	}
+	if event := mustReceive(t, events); event.Type != CacheEventEvicted || event.Key != evictee {
+		t.Errorf("expected Evicted event for %v, got %+v", evictee, event)
+	}
+}
+
+// TestCacheWatchFilter ensures a subscriber only receives events for keys
+// matching its filter.
+func TestCacheWatchFilter(t *testing.T) {
+	cache := &Cache{
+		items:   make(map[CacheKey]*CacheItem),
+		maxSize: 10,
+		pq:      make(PriorityQueue, 0),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	wanted := CacheKey{InventoryID: "nytimes.com", UtcDatetime: "2024-12-30"}
+	events, err := cache.Watch(ctx, func(key CacheKey) bool { return key.InventoryID == wanted.InventoryID })
+	if err != nil {
+		t.Fatalf("unexpected error from Watch: %v", err)
+	}
+
+	cache.SetWithTTL(CacheKey{InventoryID: "yahoo.com", UtcDatetime: "2024-12-30"}, CacheValue{Emissions: 1, Priority: 1}, time.Hour)
+	cache.SetWithTTL(wanted, CacheValue{Emissions: 2, Priority: 1}, time.Hour)
+
+	event := mustReceive(t, events)
+	if event.Key != wanted {
+		t.Errorf("expected only events for %v, got %+v", wanted, event)
+	}
+}
+
+// TestCacheWatchStopsOnContextCancel ensures the event channel is closed
+// once the Watch context is canceled.
+func TestCacheWatchStopsOnContextCancel(t *testing.T) {
+	cache := &Cache{
+		items:   make(map[CacheKey]*CacheItem),
+		maxSize: 10,
+		pq:      make(PriorityQueue, 0),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := cache.Watch(ctx, nil)
+	if err != nil {
+		t.Fatalf("unexpected error from Watch: %v", err)
+	}
+
+	cancel()
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Errorf("expected channel to be closed after context cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("timed out waiting for event channel to close")
+	}
+}
+
+func mustReceive(t *testing.T, events <-chan CacheEvent) CacheEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("timed out waiting for event")
+		return CacheEvent{}
+	}
+}
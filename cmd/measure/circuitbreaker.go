@@ -0,0 +1,171 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// BreakerState is the circuit breaker's current self-preservation state,
+// reported on /healthz so operators can see it engage during a Scope3
+// outage.
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+// BreakerConfig tunes when the circuit breaker trips into self-preservation
+// mode and how it recovers from it.
+type BreakerConfig struct {
+	Window           time.Duration // how far back failures/successes are counted
+	MinRequests      int           // samples required in Window before the error rate is trusted
+	ErrorThreshold   float64       // fraction of failures in Window that trips the breaker
+	OpenDuration     time.Duration // how long to stay Open before probing with a half-open request
+	SuccessesToClose int           // consecutive half-open successes required to close again
+}
+
+// outcome is a single recorded upstream call result.
+type outcome struct {
+	at      time.Time
+	success bool
+	latency time.Duration
+}
+
+// CircuitBreaker tracks the Scope3 upstream error rate and latency and trips
+// into a degraded, self-preserving mode when failures exceed
+// config.ErrorThreshold within config.Window. Borrowed from the
+// self-preservation pattern used by service registries such as Eureka: the
+// goal is to keep the API useful even while the upstream it depends on is
+// having an outage, rather than cascading the failure to every caller.
+type CircuitBreaker struct {
+	mu     sync.Mutex
+	config BreakerConfig
+	state  BreakerState
+
+	history         []outcome
+	openedAt        time.Time
+	halfOpenSuccess int
+	probeInFlight   bool
+
+	onTransition func(from, to BreakerState)
+}
+
+// NewCircuitBreaker creates a CircuitBreaker in the closed state. onTransition,
+// if non-nil, is called synchronously whenever the breaker changes state.
+func NewCircuitBreaker(config BreakerConfig, onTransition func(from, to BreakerState)) *CircuitBreaker {
+	return &CircuitBreaker{
+		config:       config,
+		state:        BreakerClosed,
+		onTransition: onTransition,
+	}
+}
+
+// State reports the breaker's current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a new upstream call should be attempted. While Open,
+// calls are rejected until config.OpenDuration has elapsed, at which point
+// the breaker moves to HalfOpen and allows a single probe call through. While
+// HalfOpen, one further probe is allowed each time the previous one's result
+// has been recorded.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.config.OpenDuration {
+			return false
+		}
+		b.transition(BreakerHalfOpen)
+		b.probeInFlight = true
+		return true
+	case BreakerHalfOpen:
+		// A probe is already in flight; don't let a second one through.
+		if b.probeInFlight {
+			return false
+		}
+		b.probeInFlight = true
+		return true
+	default:
+		return true
+	}
+}
+
+// RecordResult records the outcome of an upstream call and re-evaluates the
+// breaker's state.
+func (b *CircuitBreaker) RecordResult(success bool, latency time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.history = append(b.history, outcome{at: now, success: success, latency: latency})
+	b.prune(now)
+	b.probeInFlight = false
+
+	switch b.state {
+	case BreakerHalfOpen:
+		if success {
+			b.halfOpenSuccess++
+			if b.halfOpenSuccess >= b.config.SuccessesToClose {
+				b.transition(BreakerClosed)
+			}
+		} else {
+			b.transition(BreakerOpen)
+		}
+	default:
+		if b.shouldTrip() {
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+// prune drops outcomes older than config.Window. The caller must hold b.mu.
+func (b *CircuitBreaker) prune(now time.Time) {
+	cutoff := now.Add(-b.config.Window)
+	i := 0
+	for ; i < len(b.history); i++ {
+		if b.history[i].at.After(cutoff) {
+			break
+		}
+	}
+	b.history = b.history[i:]
+}
+
+// shouldTrip reports whether the recent error rate meets or exceeds
+// config.ErrorThreshold. The caller must hold b.mu.
+func (b *CircuitBreaker) shouldTrip() bool {
+	if len(b.history) < b.config.MinRequests {
+		return false
+	}
+	failures := 0
+	for _, o := range b.history {
+		if !o.success {
+			failures++
+		}
+	}
+	return float64(failures)/float64(len(b.history)) >= b.config.ErrorThreshold
+}
+
+// transition moves the breaker to state to, resets half-open bookkeeping,
+// and notifies onTransition if set. The caller must hold b.mu.
+func (b *CircuitBreaker) transition(to BreakerState) {
+	from := b.state
+	if from == to {
+		return
+	}
+	b.state = to
+	if to == BreakerOpen {
+		b.openedAt = time.Now()
+	}
+	b.halfOpenSuccess = 0
+	if b.onTransition != nil {
+		b.onTransition(from, to)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCircuitBreakerTripsOnErrorRate ensures the breaker opens once the
+// error rate within its window meets the configured threshold.
+func TestCircuitBreakerTripsOnErrorRate(t *testing.T) {
+	var transitions []BreakerState
+	breaker := NewCircuitBreaker(BreakerConfig{
+		Window:         time.Minute,
+		MinRequests:    4,
+		ErrorThreshold: 0.5,
+		OpenDuration:   time.Hour,
+	}, func(from, to BreakerState) {
+		transitions = append(transitions, to)
+	})
+
+	breaker.RecordResult(true, time.Millisecond)
+	breaker.RecordResult(true, time.Millisecond)
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected breaker to stay closed below MinRequests, got %s", breaker.State())
+	}
+
+	breaker.RecordResult(false, time.Millisecond)
+	breaker.RecordResult(false, time.Millisecond)
+
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected breaker to open once error rate met threshold, got %s", breaker.State())
+	}
+	if len(transitions) != 1 || transitions[0] != BreakerOpen {
+		t.Errorf("expected exactly one transition to Open, got %v", transitions)
+	}
+}
+
+// TestCircuitBreakerRecoversThroughHalfOpen ensures an Open breaker rejects
+// calls until OpenDuration elapses, then probes via HalfOpen and closes
+// again once enough successes are recorded.
+func TestCircuitBreakerRecoversThroughHalfOpen(t *testing.T) {
+	breaker := NewCircuitBreaker(BreakerConfig{
+		Window:           time.Minute,
+		MinRequests:      1,
+		ErrorThreshold:   0.5,
+		OpenDuration:     10 * time.Millisecond,
+		SuccessesToClose: 2,
+	}, nil)
+
+	breaker.RecordResult(false, time.Millisecond)
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expected breaker to open after a failure, got %s", breaker.State())
+	}
+	if breaker.Allow() {
+		t.Errorf("expected Allow to reject calls immediately after opening")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+
+	if !breaker.Allow() {
+		t.Fatalf("expected Allow to let a probe through once OpenDuration elapsed")
+	}
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("expected breaker to be HalfOpen after a probe was let through, got %s", breaker.State())
+	}
+
+	breaker.RecordResult(true, time.Millisecond)
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("expected breaker to stay HalfOpen before SuccessesToClose is reached, got %s", breaker.State())
+	}
+
+	breaker.RecordResult(true, time.Millisecond)
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expected breaker to close after enough HalfOpen successes, got %s", breaker.State())
+	}
+}
+
+// TestCircuitBreakerHalfOpenFailureReopens ensures a failed probe during
+// HalfOpen sends the breaker straight back to Open.
+func TestCircuitBreakerHalfOpenFailureReopens(t *testing.T) {
+	breaker := NewCircuitBreaker(BreakerConfig{
+		Window:         time.Minute,
+		MinRequests:    1,
+		ErrorThreshold: 0.5,
+		OpenDuration:   10 * time.Millisecond,
+	}, nil)
+
+	breaker.RecordResult(false, time.Millisecond)
+	time.Sleep(15 * time.Millisecond)
+	breaker.Allow() // moves to HalfOpen
+
+	breaker.RecordResult(false, time.Millisecond)
+	if breaker.State() != BreakerOpen {
+		t.Errorf("expected a failed HalfOpen probe to reopen the breaker, got %s", breaker.State())
+	}
+}
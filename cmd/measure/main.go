@@ -3,11 +3,15 @@ package main
 import (
 	"bytes"
 	"container/heap"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -19,19 +23,44 @@ import (
 // It uses a priority queue to manage the items based on their priority and timestamp.
 //
 // Fields:
-// - mu: A mutex to ensure thread-safe access to the cache.
-// - items: A map to store cache items with their keys.
-// - maxSize: The maximum number of items the cache can hold.
-// - currSize: The current number of items in the cache.
-// - pq: A priority queue to manage the cache items based on their priority and timestamp.
-// - onEvict: A callback function that is called when an item is evicted from the cache.
+//   - mu: A mutex to ensure thread-safe access to the cache.
+//   - items: A map to store cache items with their keys.
+//   - maxSize: The maximum number of items the cache can hold.
+//   - currSize: The current number of items in the cache.
+//   - pq: A priority queue to manage the cache items based on their priority and timestamp.
+//   - subMu: A mutex guarding subs, the set of active Watch subscribers.
+//   - subs: The set of active Watch subscribers notified of every change.
+//   - staleMode: When non-zero, expired entries are kept (with their expiry
+//     extended) instead of evicted, so callers can still be served stale data
+//     during an upstream outage. Toggled by CircuitBreaker via SetStaleMode.
 type Cache struct {
 	mu       sync.Mutex
 	items    map[CacheKey]*CacheItem
 	maxSize  int64
 	currSize int64
 	pq       PriorityQueue
-	onEvict  func(key CacheKey, value CacheValue)
+
+	subMu sync.RWMutex
+	subs  map[*cacheSubscriber]struct{}
+
+	staleMode int32
+}
+
+// SetStaleMode toggles whether expired entries are evicted on TTL (the
+// default) or kept with their expiry extended so they can still be served
+// as stale data. The circuit breaker enables this while it is Open or
+// HalfOpen and disables it again once it closes.
+func (c *Cache) SetStaleMode(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&c.staleMode, v)
+}
+
+// staleModeEnabled reports the current value set by SetStaleMode.
+func (c *Cache) staleModeEnabled() bool {
+	return atomic.LoadInt32(&c.staleMode) == 1
 }
 
 // CacheItem represents an item stored in the cache with an expiry time and priority.
@@ -85,15 +114,54 @@ func (pq *PriorityQueue) Pop() interface{} {
 
 // Get retrieves a value from the cache by its key. It returns the value and a boolean indicating whether the key was found.
 func (c *Cache) Get(key CacheKey) (CacheValue, bool) {
+	value, found, _ := c.GetAllowStale(key, false)
+	return value, found
+}
+
+// GetAllowStale behaves like Get, except that when allowStale is true an
+// entry whose TTL has already passed is still returned instead of treated
+// as missing. stale reports whether the returned value was past its expiry.
+func (c *Cache) GetAllowStale(key CacheKey, allowStale bool) (value CacheValue, found bool, stale bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	item, ok := c.items[key]
+	if !ok {
+		return CacheValue{}, false, false
+	}
+
+	if item.expiry.Before(time.Now()) {
+		if !allowStale {
+			return CacheValue{}, false, false
+		}
+		return item.value, true, true
+	}
+
+	return item.value, true, false
+}
+
+// Delete removes key from the cache immediately, notifying Watch
+// subscribers with an Evicted event. It is a no-op if key is not present.
+func (c *Cache) Delete(key CacheKey) {
+	c.mu.Lock()
 	item, found := c.items[key]
-	if !found || item.expiry.Before(time.Now()) {
-		return CacheValue{}, false
+	if !found {
+		c.mu.Unlock()
+		return
 	}
+	heap.Remove(&c.pq, item.index)
+	delete(c.items, key)
+	c.currSize -= 1
+	c.mu.Unlock()
+
+	c.notify(CacheEvent{Type: CacheEventEvicted, Key: item.key, Value: item.value})
+}
 
-	return item.value, true
+// Len reports the number of entries currently in the cache.
+func (c *Cache) Len() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.currSize
 }
 
 // SetWithTTL adds a key-value pair to the cache with a specified time-to-live (TTL). It evicts items if the cache exceeds its maximum size.
@@ -101,7 +169,9 @@ func (c *Cache) SetWithTTL(key CacheKey, value CacheValue, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	eventType := CacheEventAdded
 	if item, found := c.items[key]; found {
+		eventType = CacheEventUpdated
 		c.currSize -= 1
 		heap.Remove(&c.pq, item.index)
 	}
@@ -122,52 +192,143 @@ func (c *Cache) SetWithTTL(key CacheKey, value CacheValue, ttl time.Duration) {
 		c.mu.Lock()
 		defer c.mu.Unlock()
 		if item, found := c.items[key]; found && item.expiry.Before(time.Now()) {
+			if c.staleModeEnabled() {
+				// Self-preservation mode: keep serving this entry as stale
+				// instead of evicting it while the upstream is unhealthy.
+				item.expiry = time.Now().Add(ttl)
+				return
+			}
 			heap.Remove(&c.pq, item.index)
 			delete(c.items, key)
 			c.currSize -= 1
-			if c.onEvict != nil {
-				c.onEvict(item.key, item.value)
-			}
+			c.notify(CacheEvent{Type: CacheEventExpired, Key: item.key, Value: item.value})
 		}
 	}(key, ttl)
 
+	c.notify(CacheEvent{Type: eventType, Key: key, Value: value})
+
 	for c.currSize > c.maxSize {
 		evicted := heap.Pop(&c.pq).(*CacheItem)
 		delete(c.items, evicted.key)
 		c.currSize -= 1
-		if c.onEvict != nil {
-			c.onEvict(evicted.key, evicted.value)
-		}
+		c.notify(CacheEvent{Type: CacheEventEvicted, Key: evicted.key, Value: evicted.value})
 	}
 }
 
 // Config represents the configuration for the server, including cache expiration duration.
 type Config struct {
-	CacheExpiration time.Duration
+	CacheExpiration            time.Duration
+	InboundLimit               RateLimitConfig
+	OutboundLimit              RateLimitConfig
+	Breaker                    BreakerConfig
+	MaxRowsPerUpstreamCall     int
+	MaxConcurrentUpstreamCalls int
 }
 
 // Server represents the server that handles HTTP requests and manages the cache.
 type Server struct {
-	Cache      *Cache
-	HTTPClient *http.Client
-	APIKey     string
-	Logger     zerolog.Logger
-	Config     *Config
+	Cache           Store
+	HTTPClient      *http.Client
+	APIKey          string
+	Logger          zerolog.Logger
+	InboundLimiter  RateLimiter
+	OutboundLimiter RateLimiter
+	BaseURL         string
+	Breaker         *CircuitBreaker
+	Metrics         *Metrics
+	singleflight    *singleflightGroup
+
+	configMu sync.RWMutex
+	config   *Config
+}
+
+// CacheExpiration returns the TTL applied to new cache entries, safe to call
+// concurrently with AdminConfigHandler's hot reload.
+func (s *Server) CacheExpiration() time.Duration {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config.CacheExpiration
+}
+
+// SetCacheExpiration updates the TTL applied to new cache entries. Entries
+// already in the cache keep whatever TTL they were written with.
+func (s *Server) SetCacheExpiration(d time.Duration) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config.CacheExpiration = d
 }
 
-// CreateServer initializes a new server instance with the specified cache size and expiration duration.
+// MaxRowsPerUpstreamCall returns the maximum number of rows sent to Scope3
+// in a single upstream call before EmissionHandler splits a request into
+// chunks.
+func (s *Server) MaxRowsPerUpstreamCall() int {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config.MaxRowsPerUpstreamCall
+}
+
+// MaxConcurrentUpstreamCalls returns how many chunked upstream calls
+// EmissionHandler may have in flight at once.
+func (s *Server) MaxConcurrentUpstreamCalls() int {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config.MaxConcurrentUpstreamCalls
+}
+
+// CreateServer initializes a new server instance with the specified cache
+// size and expiration duration, selecting the Store backend via the
+// CACHE_BACKEND environment variable (memory, the default; bolt; or
+// redis). Use CreateServerWithStore instead to supply a backend directly.
 func CreateServer(cacheMaxCost int64, cacheExpirationInMilliSeconds time.Duration) (*Server, error) {
+	env, err := loadServerEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := NewMetrics()
+	store, err := newStoreFromEnv(cacheMaxCost, env.logger, metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	return newServerWithStore(store, cacheExpirationInMilliSeconds, env, metrics)
+}
+
+// CreateServerWithStore builds a Server around a caller-provided Store,
+// bypassing CACHE_BACKEND selection. This is how a custom or pre-warmed
+// Store (or one built outside of newStoreFromEnv's defaults) gets wired up.
+func CreateServerWithStore(store Store, cacheExpirationInMilliSeconds time.Duration) (*Server, error) {
+	env, err := loadServerEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return newServerWithStore(store, cacheExpirationInMilliSeconds, env, NewMetrics())
+}
+
+// serverEnv is the environment-derived configuration shared by CreateServer
+// and CreateServerWithStore.
+type serverEnv struct {
+	apiKey        string
+	logger        zerolog.Logger
+	inboundLimit  RateLimitConfig
+	outboundLimit RateLimitConfig
+}
+
+// loadServerEnv loads the .env file, the Scope3 API token, the logger, and
+// the rate-limit configuration shared by every Server constructor.
+func loadServerEnv() (serverEnv, error) {
 	err := godotenv.Load()
 	if err != nil {
 		err = godotenv.Load("../../.env")
 		if err != nil {
-			return nil, fmt.Errorf("Error loading .env file: %s", err)
+			return serverEnv{}, fmt.Errorf("Error loading .env file: %s", err)
 		}
 	}
 
 	apiKey := os.Getenv("SCOPE3_API_TOKEN")
 	if apiKey == "" {
-		return nil, fmt.Errorf("SCOPE3_API_TOKEN is not set in the environment variables")
+		return serverEnv{}, fmt.Errorf("SCOPE3_API_TOKEN is not set in the environment variables")
 	}
 
 	logger := zerolog.New(
@@ -178,27 +339,125 @@ func CreateServer(cacheMaxCost int64, cacheExpirationInMilliSeconds time.Duratio
 		logger = zerolog.Nop()
 	}
 
-	return &Server{
-		Config: &Config{
-			CacheExpiration: cacheExpirationInMilliSeconds,
+	return serverEnv{
+		apiKey: apiKey,
+		logger: logger,
+		inboundLimit: RateLimitConfig{
+			RPS:   floatEnv("INBOUND_RPS", 10),
+			Burst: int64Env("INBOUND_BURST", 20),
+		},
+		outboundLimit: RateLimitConfig{
+			RPS:   floatEnv("OUTBOUND_RPS", 5),
+			Burst: int64Env("OUTBOUND_BURST", 10),
 		},
-		Cache: &Cache{
+	}, nil
+}
+
+// newStoreFromEnv builds the Store selected by CACHE_BACKEND: "memory"
+// (the default) for the in-process priority-queue Cache, "bolt" for
+// single-node durability across restarts, or "redis" for a cache shared
+// across multiple Server instances.
+func newStoreFromEnv(cacheMaxCost int64, logger zerolog.Logger, metrics *Metrics) (Store, error) {
+	switch backend := os.Getenv("CACHE_BACKEND"); backend {
+	case "", "memory":
+		cache := &Cache{
 			items:   make(map[CacheKey]*CacheItem),
 			maxSize: cacheMaxCost,
 			pq:      make(PriorityQueue, 0),
-			onEvict: func(key CacheKey, value CacheValue) {
-				logger := zerolog.New(
-					zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339},
-				).Level(zerolog.TraceLevel).With().Timestamp().Caller().Logger()
-				logger.Info().Msgf("Evicted key: %v, value: %v", key, value)
-			},
+		}
+		logEvictions(cache, logger, metrics)
+		return cache, nil
+	case "bolt":
+		path := os.Getenv("BOLT_PATH")
+		if path == "" {
+			path = "scope3-cache.db"
+		}
+		return NewBoltStore(path, cacheMaxCost)
+	case "redis":
+		addr := os.Getenv("REDIS_ADDR")
+		if addr == "" {
+			addr = "localhost:6379"
+		}
+		return NewRedisStore(addr, cacheMaxCost)
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q", backend)
+	}
+}
+
+// newServerWithStore assembles a Server from an already-built Store and the
+// environment-derived configuration common to every constructor.
+func newServerWithStore(store Store, cacheExpirationInMilliSeconds time.Duration, env serverEnv, metrics *Metrics) (*Server, error) {
+	breakerConfig := BreakerConfig{
+		Window:           time.Duration(floatEnv("BREAKER_WINDOW_SECONDS", 60)) * time.Second,
+		MinRequests:      int(int64Env("BREAKER_MIN_REQUESTS", 10)),
+		ErrorThreshold:   floatEnv("BREAKER_ERROR_THRESHOLD", 0.5),
+		OpenDuration:     time.Duration(floatEnv("BREAKER_OPEN_SECONDS", 30)) * time.Second,
+		SuccessesToClose: int(int64Env("BREAKER_SUCCESSES_TO_CLOSE", 3)),
+	}
+	breaker := NewCircuitBreaker(breakerConfig, func(from, to BreakerState) {
+		env.logger.Warn().Msgf("circuit breaker transitioned from %s to %s", from, to)
+		applyStaleMode(store, to != BreakerClosed)
+	})
+
+	return &Server{
+		config: &Config{
+			CacheExpiration:            cacheExpirationInMilliSeconds,
+			InboundLimit:               env.inboundLimit,
+			OutboundLimit:              env.outboundLimit,
+			Breaker:                    breakerConfig,
+			MaxRowsPerUpstreamCall:     int(int64Env("MAX_ROWS_PER_UPSTREAM_CALL", 100)),
+			MaxConcurrentUpstreamCalls: int(int64Env("MAX_CONCURRENT_UPSTREAM_CALLS", 5)),
 		},
-		HTTPClient: &http.Client{Timeout: 10 * time.Second},
-		APIKey:     apiKey,
-		Logger:     logger,
+		Cache:           store,
+		HTTPClient:      &http.Client{Timeout: 10 * time.Second},
+		APIKey:          env.apiKey,
+		Logger:          env.logger,
+		InboundLimiter:  NewTokenBucketLimiter(env.inboundLimit),
+		OutboundLimiter: NewTokenBucketLimiter(env.outboundLimit),
+		BaseURL:         "https://api.scope3.com",
+		Breaker:         breaker,
+		Metrics:         metrics,
+		singleflight:    newSingleflightGroup(),
 	}, nil
 }
 
+// logEvictions subscribes to cache's Evicted and Expired events for the
+// lifetime of the process, logging them and recording them on metrics,
+// replacing the old onEvict callback with an ordinary internal Watch
+// subscriber.
+func logEvictions(cache *Cache, logger zerolog.Logger, metrics *Metrics) {
+	events, _ := cache.Watch(context.Background(), nil)
+	go func() {
+		for event := range events {
+			switch event.Type {
+			case CacheEventEvicted, CacheEventExpired:
+				logger.Info().Msgf("%s key: %v, value: %v", event.Type, event.Key, event.Value)
+				metrics.RecordEviction(event.Type)
+			}
+		}
+	}()
+}
+
+// floatEnv reads name from the environment and parses it as a float64,
+// falling back to def when the variable is unset or unparsable.
+func floatEnv(name string, def float64) float64 {
+	v, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// int64Env reads name from the environment and parses it as an int64,
+// falling back to def when the variable is unset or unparsable.
+func int64Env(name string, def int64) int64 {
+	v, err := strconv.ParseInt(os.Getenv(name), 10, 64)
+	if err != nil {
+		return def
+	}
+	return v
+}
+
 // Inventory represents the input data structure for inventory items.
 type Inventory struct {
 	InventoryID string `json:"inventoryId" binding:"required"`
@@ -210,6 +469,7 @@ type Inventory struct {
 type EmissionData struct {
 	InventoryID string  `json:"inventoryId"`
 	Emissions   float64 `json:"totalEmissions"`
+	Error       string  `json:"error,omitempty"`
 }
 
 // CacheKey represents the key structure for the cache.
@@ -224,12 +484,45 @@ type CacheValue struct {
 	Priority  uint8
 }
 
-// getEmissionDataFromInternalAPI fetches emission data from an internal API.
-func (s *Server) getEmissionDataFromInternalAPI(rows map[string]Inventory) ([]EmissionData, error) {
+// getEmissionDataFromInternalAPI fetches emission data for a single chunk of
+// rows from the internal API. The outbound budget is enforced both per
+// API key, so one tenant can't exhaust the shared Scope3 quota, and per
+// inventoryId, so repeated lookups for a single hot inventoryId can't starve
+// the rest of that same tenant's batch.
+func (s *Server) getEmissionDataFromInternalAPI(ctx context.Context, rows map[string]Inventory) ([]EmissionData, error) {
 	s.Logger.Trace().Msg("Entry")
 	defer s.Logger.Trace().Msg("Exit")
 
-	url := "https://api.scope3.com/v2/measure?includeRows=true&latest=true&fields=emissionsBreakdown"
+	if allowed, retryAfter, err := s.OutboundLimiter.Allow(ctx, s.APIKey); err != nil {
+		return nil, err
+	} else if !allowed {
+		s.Logger.Warn().Msgf("outbound rate limit exceeded, retry after %s", retryAfter)
+		return nil, fmt.Errorf("%w: retry after %s", ErrRateLimited, retryAfter)
+	}
+
+	for _, row := range rows {
+		if allowed, retryAfter, err := s.OutboundLimiter.Allow(ctx, row.InventoryID); err != nil {
+			return nil, err
+		} else if !allowed {
+			s.Logger.Warn().Msgf("outbound rate limit exceeded for inventoryId %s, retry after %s", row.InventoryID, retryAfter)
+			return nil, fmt.Errorf("%w: retry after %s", ErrRateLimited, retryAfter)
+		}
+	}
+
+	start := time.Now()
+	emissionData, err := s.callScope3(ctx, rows)
+	latency := time.Since(start)
+	s.Breaker.RecordResult(err == nil, latency)
+	s.Metrics.ObserveUpstreamCall(err == nil, latency)
+	return emissionData, err
+}
+
+// callScope3 performs the actual HTTP round trip to Scope3. It is split out
+// from getEmissionDataFromInternalAPI so that only genuine upstream
+// failures, not rate-limiting decisions made before the call, feed the
+// circuit breaker.
+func (s *Server) callScope3(ctx context.Context, rows map[string]Inventory) ([]EmissionData, error) {
+	url := s.BaseURL + "/v2/measure?includeRows=true&latest=true&fields=emissionsBreakdown"
 
 	// Prepare request body
 	var requestBodyRows []map[string]interface{}
@@ -255,7 +548,7 @@ func (s *Server) getEmissionDataFromInternalAPI(rows map[string]Inventory) ([]Em
 	s.Logger.Debug().Msgf("requestBody: %s", requestBody)
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
 	if err != nil {
 		s.Logger.Error().Msgf("failed to create request: %s", err)
 		return nil, err
@@ -327,42 +620,124 @@ func (s *Server) EmissionHandler(c *gin.Context) {
 	var result []EmissionData
 	var cacheMisses map[string]Inventory = make(map[string]Inventory)
 
+	// While the breaker is not closed, fall back to serving entries even
+	// past their TTL rather than treating them as misses.
+	degraded := s.Breaker.State() != BreakerClosed
+	servedStale := false
+
 	// Check cache for each row
 	for _, row := range reqBody.Rows {
 		key := CacheKey{InventoryID: row.InventoryID, UtcDatetime: row.UtcDatetime}
-		if value, found := s.Cache.Get(key); found {
-			s.Logger.Info().Msgf("Cache hit for key: %v", key)
+		if value, found, stale := s.getAllowStale(key, degraded); found {
+			s.Logger.Info().Msgf("Cache hit for key: %v (stale: %v)", key, stale)
+			s.Metrics.RecordCacheHit()
+			servedStale = servedStale || stale
 			result = append(result, EmissionData{InventoryID: key.InventoryID, Emissions: value.Emissions})
 		} else {
 			s.Logger.Info().Msgf("Cache miss for key: %v", key)
+			s.Metrics.RecordCacheMiss()
 			cacheMisses[key.InventoryID] = row
 		}
 	}
 
+	if servedStale {
+		c.Header("X-Cache-Status", "stale")
+	}
+
 	if len(cacheMisses) > 0 {
-		s.Logger.Info().Msg("Getting emission data from internal API")
-		resBody, err := s.getEmissionDataFromInternalAPI(cacheMisses)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if c.Query("force") != "true" && !s.Breaker.Allow() {
+			s.Logger.Warn().Msg("circuit breaker open, short-circuiting upstream call")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "upstream is unhealthy; pass ?force=true to bypass"})
 			return
 		}
 
-		// Append the fetched emission data to the result slice
+		// Only the first caller for a given CacheKey fetches it upstream;
+		// concurrent callers asking for the same key wait on that call's
+		// result instead of issuing a duplicate Scope3 request.
+		toFetch, waiting := s.singleflight.dedupe(cacheMisses)
+
+		partial := c.Query("partial") == "true"
+
+		var resBody []EmissionData
+		var failedRows map[string]error
+		var fetchErr error
+		if len(toFetch) > 0 {
+			s.Logger.Info().Msg("Getting emission data from internal API")
+			resBody, failedRows, fetchErr = s.fetchEmissionDataChunked(c.Request.Context(), toFetch, partial)
+
+			resultsByID := make(map[string]EmissionData, len(resBody))
+			for _, data := range resBody {
+				resultsByID[data.InventoryID] = data
+			}
+			s.singleflight.done(toFetch, resultsByID, failedRows, fetchErr)
+		}
+
+		if fetchErr != nil {
+			if errors.Is(fetchErr, ErrRateLimited) {
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": fetchErr.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fetchErr.Error()})
+			return
+		}
+
+		// Append the fetched emission data, and any per-row failures when
+		// ?partial=true, to the result slice.
 		result = append(result, resBody...)
+		for id, rowErr := range failedRows {
+			result = append(result, EmissionData{InventoryID: id, Error: rowErr.Error()})
+		}
 
 		// Add the fetched data to the cache
 		for _, data := range resBody {
 			if v, ok := cacheMisses[data.InventoryID]; ok {
 				key := CacheKey{InventoryID: data.InventoryID, UtcDatetime: v.UtcDatetime}
-				s.Cache.SetWithTTL(key, CacheValue{Emissions: data.Emissions, Priority: v.Priority}, s.Config.CacheExpiration)
+				s.Cache.SetWithTTL(key, CacheValue{Emissions: data.Emissions, Priority: v.Priority}, s.CacheExpiration())
 				s.Logger.Info().Msgf("Added to cache: %v", key)
 			}
 		}
+
+		// With ?partial=true, a row whose in-flight call failed is reported
+		// with a row-level error instead of failing the whole request.
+		if partial {
+			for key, inFlight := range waiting {
+				inFlight.wg.Wait()
+				if inFlight.err != nil {
+					result = append(result, EmissionData{InventoryID: key.InventoryID, Error: inFlight.err.Error()})
+					delete(waiting, key)
+				}
+			}
+		}
+
+		waitResults, err := waitAll(waiting)
+		if err != nil {
+			if errors.Is(err, ErrRateLimited) {
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for key, value := range waitResults {
+			result = append(result, EmissionData{InventoryID: key.InventoryID, Emissions: value.Emissions})
+			s.Cache.SetWithTTL(key, value, s.CacheExpiration())
+			s.Logger.Info().Msgf("Added to cache from in-flight call: %v", key)
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{"rows": result})
 }
 
+// HealthzHandler reports the Server's health, including the circuit
+// breaker's current self-preservation state, so operators can see it
+// engage during a Scope3 outage.
+func (s *Server) HealthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "ok",
+		"breakerState": s.Breaker.State(),
+	})
+}
+
 // main initializes the Gin router and starts the HTTP server.
 func main() {
 	// Create a new server instance.
@@ -373,7 +748,17 @@ func main() {
 	}
 
 	router := gin.Default()
-	router.POST("/measure", server.EmissionHandler)
+	router.POST("/measure", RateLimitMiddleware(server.InboundLimiter, apiKeyFromRequest), server.EmissionHandler)
+	router.GET("/events", server.EventsHandler)
+	router.GET("/healthz", server.HealthzHandler)
+	router.GET("/metrics", server.MetricsHandler)
+
+	admin := router.Group("/admin", requireAdminToken())
+	admin.GET("/cache/stats", server.AdminStatsHandler)
+	admin.GET("/cache/entries", server.AdminEntriesHandler)
+	admin.DELETE("/cache/entry/:inventoryId/:date", server.AdminDeleteEntryHandler)
+	admin.POST("/cache/flush", server.AdminFlushHandler)
+	admin.POST("/config", server.AdminConfigHandler)
 
 	router.Run(":8080")
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBucketBounds are the upper bounds (in seconds) of the upstream
+// latency histogram exposed on /metrics, chosen to span a healthy Scope3
+// call down to one slow enough to worry an operator.
+var latencyBucketBounds = []time.Duration{
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// Metrics accumulates the counters and histograms exposed by
+// Server.MetricsHandler and Server.AdminStatsHandler. All fields are safe for
+// concurrent use.
+type Metrics struct {
+	cacheHits      int64
+	cacheMisses    int64
+	evictedCount   int64
+	expiredCount   int64
+	upstreamCalls  int64
+	upstreamErrors int64
+
+	latencyMu      sync.Mutex
+	latencyBuckets []int64 // cumulative counts, one per latencyBucketBounds entry
+	latencyCount   int64
+	latencySum     time.Duration
+}
+
+// NewMetrics creates an empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{latencyBuckets: make([]int64, len(latencyBucketBounds))}
+}
+
+// RecordCacheHit increments the cache hit counter.
+func (m *Metrics) RecordCacheHit() {
+	atomic.AddInt64(&m.cacheHits, 1)
+}
+
+// RecordCacheMiss increments the cache miss counter.
+func (m *Metrics) RecordCacheMiss() {
+	atomic.AddInt64(&m.cacheMisses, 1)
+}
+
+// RecordEviction increments the eviction counter for reason, which should be
+// CacheEventEvicted (capacity eviction) or CacheEventExpired (TTL expiry).
+func (m *Metrics) RecordEviction(reason CacheEventType) {
+	switch reason {
+	case CacheEventEvicted:
+		atomic.AddInt64(&m.evictedCount, 1)
+	case CacheEventExpired:
+		atomic.AddInt64(&m.expiredCount, 1)
+	}
+}
+
+// ObserveUpstreamCall records the outcome and latency of one Scope3 call.
+func (m *Metrics) ObserveUpstreamCall(success bool, latency time.Duration) {
+	atomic.AddInt64(&m.upstreamCalls, 1)
+	if !success {
+		atomic.AddInt64(&m.upstreamErrors, 1)
+	}
+
+	m.latencyMu.Lock()
+	defer m.latencyMu.Unlock()
+	m.latencyCount++
+	m.latencySum += latency
+	for i, bound := range latencyBucketBounds {
+		if latency <= bound {
+			m.latencyBuckets[i]++
+		}
+	}
+}
+
+// MetricsSnapshot is a point-in-time copy of Metrics, used by AdminStatsHandler.
+type MetricsSnapshot struct {
+	CacheHits      int64   `json:"cacheHits"`
+	CacheMisses    int64   `json:"cacheMisses"`
+	HitRate        float64 `json:"hitRate"`
+	EvictedCount   int64   `json:"evictedCount"`
+	ExpiredCount   int64   `json:"expiredCount"`
+	UpstreamCalls  int64   `json:"upstreamCalls"`
+	UpstreamErrors int64   `json:"upstreamErrors"`
+}
+
+// Snapshot returns the current counter values and derived hit rate.
+func (m *Metrics) Snapshot() MetricsSnapshot {
+	hits := atomic.LoadInt64(&m.cacheHits)
+	misses := atomic.LoadInt64(&m.cacheMisses)
+
+	var hitRate float64
+	if total := hits + misses; total > 0 {
+		hitRate = float64(hits) / float64(total)
+	}
+
+	return MetricsSnapshot{
+		CacheHits:      hits,
+		CacheMisses:    misses,
+		HitRate:        hitRate,
+		EvictedCount:   atomic.LoadInt64(&m.evictedCount),
+		ExpiredCount:   atomic.LoadInt64(&m.expiredCount),
+		UpstreamCalls:  atomic.LoadInt64(&m.upstreamCalls),
+		UpstreamErrors: atomic.LoadInt64(&m.upstreamErrors),
+	}
+}
+
+// WriteProm renders m, plus queueDepth (the Store's current entry count), in
+// Prometheus text exposition format.
+func (m *Metrics) WriteProm(queueDepth int64) string {
+	snap := m.Snapshot()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP scope3_cache_hits_total Cache hits served without an upstream call.\n")
+	fmt.Fprintf(&b, "# TYPE scope3_cache_hits_total counter\n")
+	fmt.Fprintf(&b, "scope3_cache_hits_total %d\n", snap.CacheHits)
+
+	fmt.Fprintf(&b, "# HELP scope3_cache_misses_total Cache misses that required an upstream call.\n")
+	fmt.Fprintf(&b, "# TYPE scope3_cache_misses_total counter\n")
+	fmt.Fprintf(&b, "scope3_cache_misses_total %d\n", snap.CacheMisses)
+
+	fmt.Fprintf(&b, "# HELP scope3_cache_evictions_total Cache entries removed, by reason.\n")
+	fmt.Fprintf(&b, "# TYPE scope3_cache_evictions_total counter\n")
+	fmt.Fprintf(&b, "scope3_cache_evictions_total{reason=\"capacity\"} %d\n", snap.EvictedCount)
+	fmt.Fprintf(&b, "scope3_cache_evictions_total{reason=\"expired\"} %d\n", snap.ExpiredCount)
+
+	fmt.Fprintf(&b, "# HELP scope3_cache_queue_depth Entries currently held by the configured cache backend.\n")
+	fmt.Fprintf(&b, "# TYPE scope3_cache_queue_depth gauge\n")
+	fmt.Fprintf(&b, "scope3_cache_queue_depth %d\n", queueDepth)
+
+	fmt.Fprintf(&b, "# HELP scope3_upstream_calls_total Scope3 API calls, by outcome.\n")
+	fmt.Fprintf(&b, "# TYPE scope3_upstream_calls_total counter\n")
+	fmt.Fprintf(&b, "scope3_upstream_calls_total{outcome=\"success\"} %d\n", snap.UpstreamCalls-snap.UpstreamErrors)
+	fmt.Fprintf(&b, "scope3_upstream_calls_total{outcome=\"error\"} %d\n", snap.UpstreamErrors)
+
+	m.latencyMu.Lock()
+	buckets := append([]int64(nil), m.latencyBuckets...)
+	count := m.latencyCount
+	sum := m.latencySum
+	m.latencyMu.Unlock()
+
+	fmt.Fprintf(&b, "# HELP scope3_upstream_latency_seconds Scope3 API call latency.\n")
+	fmt.Fprintf(&b, "# TYPE scope3_upstream_latency_seconds histogram\n")
+	for i, bound := range latencyBucketBounds {
+		fmt.Fprintf(&b, "scope3_upstream_latency_seconds_bucket{le=\"%g\"} %d\n", bound.Seconds(), buckets[i])
+	}
+	fmt.Fprintf(&b, "scope3_upstream_latency_seconds_bucket{le=\"+Inf\"} %d\n", count)
+	fmt.Fprintf(&b, "scope3_upstream_latency_seconds_sum %g\n", sum.Seconds())
+	fmt.Fprintf(&b, "scope3_upstream_latency_seconds_count %d\n", count)
+
+	return b.String()
+}
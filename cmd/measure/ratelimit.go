@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrRateLimited is returned by callers of a RateLimiter (and by
+// Server.getEmissionDataFromInternalAPI) when a request was rejected because
+// it would exceed the configured budget.
+var ErrRateLimited = fmt.Errorf("rate limit exceeded")
+
+// RateLimiter decides whether a caller identified by key may proceed right
+// now. Implementations may enforce the limit purely in-process or delegate
+// to an external coordinator over gRPC so multiple Server instances can
+// share one budget, similar in spirit to Gubernator.
+type RateLimiter interface {
+	// Allow reports whether a single request for key is permitted. When it
+	// is not, retryAfter indicates how long the caller should wait before
+	// retrying.
+	Allow(ctx context.Context, key string) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// RateLimitConfig is the requests-per-second and burst allowance applied to
+// every key tracked by a RateLimiter.
+type RateLimitConfig struct {
+	RPS   float64
+	Burst int64
+}
+
+// bucketIdleTTL bounds how long an unused bucket is kept around. Without
+// this, a TokenBucketLimiter keyed by something high-cardinality and
+// long-lived, like an inventoryId, would accumulate one permanent entry per
+// distinct key ever seen for the life of the process.
+const bucketIdleTTL = 10 * time.Minute
+
+// tokenBucket tracks the tokens currently available for a single
+// rate-limited key.
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	rps      float64
+	last     time.Time
+	lastUsed time.Time
+}
+
+// TokenBucketLimiter is an in-process RateLimiter keyed by an arbitrary
+// string, such as a caller's API key or an inventoryId. Each key gets its
+// own bucket; a background goroutine refills every bucket once a second so
+// keys that have been idle are credited with tokens even without an
+// intervening Allow call, and evicts buckets that have gone unused for
+// longer than bucketIdleTTL so the map doesn't grow without bound.
+type TokenBucketLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	config  RateLimitConfig
+	stop    chan struct{}
+}
+
+// NewTokenBucketLimiter creates a TokenBucketLimiter enforcing cfg for every
+// key and starts its periodic refill goroutine.
+func NewTokenBucketLimiter(cfg RateLimitConfig) *TokenBucketLimiter {
+	l := &TokenBucketLimiter{
+		buckets: make(map[string]*tokenBucket),
+		config:  cfg,
+		stop:    make(chan struct{}),
+	}
+	go l.refillLoop()
+	return l
+}
+
+// refillLoop periodically tops up every bucket so that a key which has not
+// been used in a while still has a full budget the next time it is, and
+// evicts buckets idle for longer than bucketIdleTTL.
+func (l *TokenBucketLimiter) refillLoop() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.mu.Lock()
+			now := time.Now()
+			for key, b := range l.buckets {
+				if now.Sub(b.lastUsed) > bucketIdleTTL {
+					delete(l.buckets, key)
+					continue
+				}
+				l.refill(b, now)
+			}
+			l.mu.Unlock()
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Close stops the background refill goroutine. Safe to call once.
+func (l *TokenBucketLimiter) Close() {
+	close(l.stop)
+}
+
+// refill tops up b's tokens based on elapsed time since its last refill.
+// The caller must hold l.mu.
+func (l *TokenBucketLimiter) refill(b *tokenBucket, now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = minFloat(b.capacity, b.tokens+elapsed*b.rps)
+	b.last = now
+}
+
+// Allow implements RateLimiter using a token bucket per key.
+func (l *TokenBucketLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, found := l.buckets[key]
+	if !found {
+		b = &tokenBucket{
+			tokens:   float64(l.config.Burst),
+			capacity: float64(l.config.Burst),
+			rps:      l.config.RPS,
+			last:     now,
+		}
+		l.buckets[key] = b
+	} else {
+		l.refill(b, now)
+	}
+	b.lastUsed = now
+
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) / b.rps * float64(time.Second))
+		return false, retryAfter, nil
+	}
+
+	b.tokens--
+	return true, 0, nil
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// RemoteRateLimitClient is the subset of a generated gRPC client needed to
+// check a limit against an external coordinator. Swapping TokenBucketLimiter
+// for a RemoteRateLimiter backed by this interface lets several Server
+// instances share one budget instead of each enforcing its own.
+type RemoteRateLimitClient interface {
+	CheckLimit(ctx context.Context, key string, rps float64, burst int64) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// RemoteRateLimiter is a RateLimiter that delegates every decision to an
+// external coordinator reached over gRPC.
+type RemoteRateLimiter struct {
+	client RemoteRateLimitClient
+	config RateLimitConfig
+}
+
+// NewRemoteRateLimiter creates a RateLimiter backed by client instead of
+// in-process buckets.
+func NewRemoteRateLimiter(client RemoteRateLimitClient, cfg RateLimitConfig) *RemoteRateLimiter {
+	return &RemoteRateLimiter{client: client, config: cfg}
+}
+
+// Allow implements RateLimiter by forwarding to the remote coordinator.
+func (l *RemoteRateLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	return l.client.CheckLimit(ctx, key, l.config.RPS, l.config.Burst)
+}
+
+// RateLimitMiddleware returns a gin.HandlerFunc that rejects a request with
+// HTTP 429 and a Retry-After header once keyFunc(c) has exceeded limiter's
+// budget, and otherwise lets it through.
+func RateLimitMiddleware(limiter RateLimiter, keyFunc func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := keyFunc(c)
+		allowed, retryAfter, err := limiter.Allow(c.Request.Context(), key)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !allowed {
+			c.Header("Retry-After", fmt.Sprintf("%.0f", retryAfter.Seconds()))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// apiKeyFromRequest extracts the caller-supplied API key used to key inbound
+// rate limiting, falling back to "anonymous" when the header is absent so
+// unauthenticated callers still share a single budget rather than bypassing
+// the limiter entirely.
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	return "anonymous"
+}
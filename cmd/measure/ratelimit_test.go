@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestTokenBucketLimiterAllowsWithinBurst ensures a key can make up to Burst
+// requests back-to-back before being rejected.
+func TestTokenBucketLimiterAllowsWithinBurst(t *testing.T) {
+	limiter := NewTokenBucketLimiter(RateLimitConfig{RPS: 1, Burst: 3})
+	defer limiter.Close()
+
+	for i := 0; i < 3; i++ {
+		allowed, _, err := limiter.Allow(context.Background(), "nytimes.com")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !allowed {
+			t.Fatalf("expected request %d to be allowed", i)
+		}
+	}
+
+	allowed, retryAfter, err := limiter.Allow(context.Background(), "nytimes.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if allowed {
+		t.Errorf("expected request beyond burst to be denied")
+	}
+	if retryAfter <= 0 {
+		t.Errorf("expected a positive Retry-After, got %v", retryAfter)
+	}
+}
+
+// TestTokenBucketLimiterPerKey ensures limits for one key do not affect
+// another key.
+func TestTokenBucketLimiterPerKey(t *testing.T) {
+	limiter := NewTokenBucketLimiter(RateLimitConfig{RPS: 1, Burst: 1})
+	defer limiter.Close()
+
+	if allowed, _, _ := limiter.Allow(context.Background(), "nytimes.com"); !allowed {
+		t.Fatalf("expected first request for nytimes.com to be allowed")
+	}
+	if allowed, _, _ := limiter.Allow(context.Background(), "nytimes.com"); allowed {
+		t.Fatalf("expected second request for nytimes.com to be denied")
+	}
+	if allowed, _, _ := limiter.Allow(context.Background(), "yahoo.com"); !allowed {
+		t.Errorf("expected yahoo.com to have its own budget")
+	}
+}
+
+// TestTokenBucketLimiterEvictsIdleBuckets ensures a bucket that has gone
+// unused for longer than bucketIdleTTL is removed by the background refill
+// loop, so a high-cardinality key space like inventoryId doesn't grow the
+// map without bound.
+func TestTokenBucketLimiterEvictsIdleBuckets(t *testing.T) {
+	limiter := NewTokenBucketLimiter(RateLimitConfig{RPS: 1, Burst: 1})
+	defer limiter.Close()
+
+	if allowed, _, _ := limiter.Allow(context.Background(), "nytimes.com"); !allowed {
+		t.Fatalf("expected first request to be allowed")
+	}
+
+	limiter.mu.Lock()
+	limiter.buckets["nytimes.com"].lastUsed = time.Now().Add(-2 * bucketIdleTTL)
+	limiter.mu.Unlock()
+
+	time.Sleep(1200 * time.Millisecond) // let the refill loop's next tick sweep it
+
+	limiter.mu.Lock()
+	_, found := limiter.buckets["nytimes.com"]
+	limiter.mu.Unlock()
+	if found {
+		t.Errorf("expected idle bucket to be evicted")
+	}
+}
+
+// TestRateLimitMiddlewareRejects ensures the middleware returns 429 with
+// Retry-After once the limiter denies a request.
+func TestRateLimitMiddlewareRejects(t *testing.T) {
+	limiter := NewTokenBucketLimiter(RateLimitConfig{RPS: 1, Burst: 1})
+	defer limiter.Close()
+
+	r := gin.Default()
+	r.GET("/", RateLimitMiddleware(limiter, func(c *gin.Context) string { return "shared" }), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected first request to pass, got %v", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected second request to be rate limited, got %v", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected Retry-After header to be set")
+	}
+}
+
+// recordingRateLimiter is a RateLimiter that allows every request but
+// records the keys it was asked about, so tests can assert which keys a call
+// site checked against.
+type recordingRateLimiter struct {
+	keys []string
+}
+
+func (l *recordingRateLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	l.keys = append(l.keys, key)
+	return true, 0, nil
+}
+
+// TestGetEmissionDataChecksOutboundLimitPerInventoryID ensures a hot
+// inventoryId is checked against the outbound limiter in addition to the
+// caller's API key, so a single inventoryId can't starve the rest of a
+// tenant's budget.
+func TestGetEmissionDataChecksOutboundLimitPerInventoryID(t *testing.T) {
+	upstream := fakeScope3(t, 0, func(ids []string) {})
+	defer upstream.Close()
+
+	server, err := CreateServer(1<<30, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	server.BaseURL = upstream.URL
+	server.APIKey = "test-api-key"
+
+	limiter := &recordingRateLimiter{}
+	server.OutboundLimiter = limiter
+
+	rows := map[string]Inventory{
+		"nytimes.com": {InventoryID: "nytimes.com", UtcDatetime: "2024-12-30", Priority: 1},
+	}
+	if _, err := server.getEmissionDataFromInternalAPI(context.Background(), rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	foundAPIKey, foundInventoryID := false, false
+	for _, key := range limiter.keys {
+		switch key {
+		case server.APIKey:
+			foundAPIKey = true
+		case "nytimes.com":
+			foundInventoryID = true
+		}
+	}
+	if !foundAPIKey {
+		t.Errorf("expected outbound limiter to be checked for API key %q, got keys %v", server.APIKey, limiter.keys)
+	}
+	if !foundInventoryID {
+		t.Errorf("expected outbound limiter to be checked for inventoryId %q, got keys %v", "nytimes.com", limiter.keys)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import "sync"
+
+// call represents an in-flight getEmissionDataFromInternalAPI lookup for a
+// single CacheKey, shared by every caller that asks for the same key while
+// it is still outstanding.
+type call struct {
+	wg    sync.WaitGroup
+	value CacheValue
+	err   error
+}
+
+// singleflightGroup deduplicates concurrent upstream lookups for the same
+// CacheKey: only the first caller for a key fetches it from Scope3, and
+// every other caller waits on that call's result instead of triggering a
+// second one. It is batch-aware — if request A is already waiting on
+// nytimes.com and request B arrives asking for nytimes.com and yahoo.com,
+// dedupe only hands yahoo.com back for fetching.
+type singleflightGroup struct {
+	mu    sync.Mutex
+	calls map[CacheKey]*call
+}
+
+// newSingleflightGroup creates an empty singleflightGroup.
+func newSingleflightGroup() *singleflightGroup {
+	return &singleflightGroup{calls: make(map[CacheKey]*call)}
+}
+
+// dedupe splits rows into toFetch, the rows this caller is now responsible
+// for fetching upstream, and waiting, the in-flight calls already started by
+// another caller that this caller should wait on instead.
+func (g *singleflightGroup) dedupe(rows map[string]Inventory) (toFetch map[string]Inventory, waiting map[CacheKey]*call) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	toFetch = make(map[string]Inventory)
+	waiting = make(map[CacheKey]*call)
+
+	for id, row := range rows {
+		key := CacheKey{InventoryID: row.InventoryID, UtcDatetime: row.UtcDatetime}
+		if c, inFlight := g.calls[key]; inFlight {
+			waiting[key] = c
+			continue
+		}
+		c := &call{}
+		c.wg.Add(1)
+		g.calls[key] = c
+		toFetch[id] = row
+	}
+
+	return toFetch, waiting
+}
+
+// done records the outcome of the rows this caller fetched and wakes up
+// every other caller waiting on them. failed carries the per-row errors from
+// a partial fetch (see fetchEmissionDataChunked); a row present in failed
+// must not be treated as a successful result even though err itself is nil.
+func (g *singleflightGroup) done(rows map[string]Inventory, results map[string]EmissionData, failed map[string]error, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for id, row := range rows {
+		key := CacheKey{InventoryID: row.InventoryID, UtcDatetime: row.UtcDatetime}
+		c, ok := g.calls[key]
+		if !ok {
+			continue
+		}
+		switch {
+		case err != nil:
+			c.err = err
+		case failed[id] != nil:
+			c.err = failed[id]
+		default:
+			if data, found := results[id]; found {
+				c.value = CacheValue{Emissions: data.Emissions, Priority: row.Priority}
+			}
+		}
+		c.wg.Done()
+		delete(g.calls, key)
+	}
+}
+
+// waitAll blocks until every call in waiting has completed and returns
+// their results keyed by CacheKey.
+func waitAll(waiting map[CacheKey]*call) (map[CacheKey]CacheValue, error) {
+	results := make(map[CacheKey]CacheValue, len(waiting))
+	for key, c := range waiting {
+		c.wg.Wait()
+		if c.err != nil {
+			return nil, c.err
+		}
+		results[key] = c.value
+	}
+	return results, nil
+}
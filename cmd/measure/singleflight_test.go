@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// upstreamRow mirrors the wire shape Scope3 returns for a single row.
+type upstreamRow struct {
+	RowIdentifier  string  `json:"rowIdentifier"`
+	TotalEmissions float64 `json:"totalEmissions"`
+}
+
+// fakeScope3 starts an httptest.Server standing in for Scope3 that echoes
+// back a TotalEmissions for every rowIdentifier it was sent, after an
+// artificial delay so concurrent requests for the same key overlap instead
+// of racing to completion.
+func fakeScope3(t *testing.T, delay time.Duration, onRequest func(ids []string)) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Rows []upstreamRow `json:"rows"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode upstream request: %v", err)
+		}
+
+		var ids []string
+		for _, row := range body.Rows {
+			ids = append(ids, row.RowIdentifier)
+		}
+		onRequest(ids)
+
+		time.Sleep(delay)
+
+		var resp struct {
+			Rows []upstreamRow `json:"rows"`
+		}
+		for _, id := range ids {
+			resp.Rows = append(resp.Rows, upstreamRow{RowIdentifier: id, TotalEmissions: 1.23})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+// postMeasure sends payload to r and returns once the response is recorded.
+func postMeasure(r http.Handler, payload []byte) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(payload))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// TestEmissionHandlerSingleflightDedupesConcurrentRequests fires N goroutines
+// asking for the same key and asserts exactly one upstream call is made.
+func TestEmissionHandlerSingleflightDedupesConcurrentRequests(t *testing.T) {
+	var calls int32
+	upstream := fakeScope3(t, 50*time.Millisecond, func(ids []string) {
+		atomic.AddInt32(&calls, 1)
+	})
+	defer upstream.Close()
+
+	server, err := CreateServer(1<<30, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	server.BaseURL = upstream.URL
+
+	r := SetUpRouter()
+	r.POST("/", server.EmissionHandler)
+
+	payload := []byte(`{"rows": [{"inventoryId": "nytimes.com", "utcDatetime": "2024-12-30", "priority": 1}]}`)
+
+	const n = 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			if w := postMeasure(r, payload); w.Code != http.StatusOK {
+				t.Errorf("expected status OK, got %v", w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("expected exactly one upstream call, got %d", got)
+	}
+}
+
+// TestEmissionHandlerSingleflightBatchDedupe covers the batch-level case:
+// request A is already waiting on nytimes.com when request B arrives asking
+// for nytimes.com and yahoo.com, so only yahoo.com should trigger a new
+// upstream row.
+func TestEmissionHandlerSingleflightBatchDedupe(t *testing.T) {
+	var mu sync.Mutex
+	var requested [][]string
+	upstream := fakeScope3(t, 50*time.Millisecond, func(ids []string) {
+		mu.Lock()
+		requested = append(requested, ids)
+		mu.Unlock()
+	})
+	defer upstream.Close()
+
+	server, err := CreateServer(1<<30, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	server.BaseURL = upstream.URL
+
+	r := SetUpRouter()
+	r.POST("/", server.EmissionHandler)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		payload := []byte(`{"rows": [{"inventoryId": "nytimes.com", "utcDatetime": "2024-12-30", "priority": 1}]}`)
+		postMeasure(r, payload)
+	}()
+
+	time.Sleep(10 * time.Millisecond) // let request A register before B arrives
+
+	go func() {
+		defer wg.Done()
+		payload := []byte(`{"rows": [
+			{"inventoryId": "nytimes.com", "utcDatetime": "2024-12-30", "priority": 1},
+			{"inventoryId": "yahoo.com", "utcDatetime": "2024-12-30", "priority": 1}
+		]}`)
+		postMeasure(r, payload)
+	}()
+
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requested) != 2 {
+		t.Fatalf("expected exactly 2 upstream calls, got %d: %v", len(requested), requested)
+	}
+	for _, ids := range requested {
+		if len(ids) != 1 {
+			t.Errorf("expected each upstream call to request a single row, got %v", ids)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Store is the persistence interface behind Server.Cache. Get and
+// SetWithTTL mirror the original in-memory Cache API so existing callers
+// are unaffected by which backend is configured; Delete and Len round it
+// out for the admin API and for backends that cannot rely on an
+// in-process TTL goroutine to expire their own entries.
+type Store interface {
+	Get(key CacheKey) (CacheValue, bool)
+	SetWithTTL(key CacheKey, value CacheValue, ttl time.Duration)
+	Delete(key CacheKey)
+	Len() int64
+}
+
+// StaleStore is implemented by Store backends that can serve entries past
+// their TTL and be switched in and out of that mode. The circuit breaker
+// uses it to keep serving stale data during a Scope3 outage; backends that
+// don't implement it simply don't participate in that self-preservation
+// behavior.
+type StaleStore interface {
+	Store
+	GetAllowStale(key CacheKey, allowStale bool) (value CacheValue, found bool, stale bool)
+	SetStaleMode(enabled bool)
+}
+
+// WatchableStore is implemented by Store backends that can stream change
+// events, used by the /events SSE endpoint.
+type WatchableStore interface {
+	Store
+	Watch(ctx context.Context, filter func(CacheKey) bool) (<-chan CacheEvent, error)
+}
+
+var (
+	_ Store          = (*Cache)(nil)
+	_ StaleStore     = (*Cache)(nil)
+	_ WatchableStore = (*Cache)(nil)
+)
+
+// applyStaleMode enables or disables stale serving on store if it supports
+// StaleStore, and is a no-op otherwise.
+func applyStaleMode(store Store, enabled bool) {
+	if ss, ok := store.(StaleStore); ok {
+		ss.SetStaleMode(enabled)
+	}
+}
+
+// getAllowStale reads key from s.Cache, consulting StaleStore's extended
+// behavior when the configured backend supports it and falling back to a
+// plain Get (never stale) otherwise.
+func (s *Server) getAllowStale(key CacheKey, allowStale bool) (value CacheValue, found bool, stale bool) {
+	if ss, ok := s.Cache.(StaleStore); ok {
+		return ss.GetAllowStale(key, allowStale)
+	}
+	value, found = s.Cache.Get(key)
+	return value, found, false
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	boltDataBucket = "cache_data"
+	boltMetaBucket = "cache_meta"
+	boltKeySep     = "|"
+)
+
+// boltEntry is the on-disk representation of a single cache entry.
+type boltEntry struct {
+	Value     CacheValue `json:"value"`
+	Expiry    time.Time  `json:"expiry"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// BoltStore is a Store backed by a BoltDB file, giving single-node
+// durability across process restarts. Entries live in boltDataBucket; the
+// priority-based eviction policy is preserved by also tracking every entry
+// in an in-memory PriorityQueue, rebuilt from boltMetaBucket on startup so
+// a restart doesn't lose eviction ordering.
+type BoltStore struct {
+	db      *bbolt.DB
+	maxSize int64
+
+	mu       sync.Mutex
+	pq       PriorityQueue
+	indexOf  map[CacheKey]*CacheItem
+	currSize int64
+
+	staleMode int32
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path and
+// reconstructs its priority queue from boltMetaBucket.
+func NewBoltStore(path string, maxSize int64) (*BoltStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt store at %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists([]byte(boltDataBucket)); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists([]byte(boltMetaBucket))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize bolt buckets: %w", err)
+	}
+
+	store := &BoltStore{
+		db:      db,
+		maxSize: maxSize,
+		pq:      make(PriorityQueue, 0),
+		indexOf: make(map[CacheKey]*CacheItem),
+	}
+	if err := store.reconstructQueue(); err != nil {
+		return nil, fmt.Errorf("failed to reconstruct bolt priority queue: %w", err)
+	}
+	return store, nil
+}
+
+// reconstructQueue rebuilds the in-memory priority queue from
+// boltMetaBucket so eviction ordering survives a restart.
+func (b *BoltStore) reconstructQueue() error {
+	return b.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket([]byte(boltMetaBucket)).ForEach(func(k, v []byte) error {
+			key, err := decodeBoltKey(string(k))
+			if err != nil {
+				return err
+			}
+			var entry boltEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+			item := &CacheItem{key: key, value: entry.Value, expiry: entry.Expiry, timestamp: entry.Timestamp}
+			heap.Push(&b.pq, item)
+			b.indexOf[key] = item
+			b.currSize++
+			return nil
+		})
+	})
+}
+
+// Get retrieves a value by key, returning false if it is missing or expired.
+func (b *BoltStore) Get(key CacheKey) (CacheValue, bool) {
+	value, found, _ := b.GetAllowStale(key, false)
+	return value, found
+}
+
+// GetAllowStale behaves like Get but, when allowStale is true or
+// SetStaleMode(true) is in effect, also returns an entry whose expiry has
+// already passed.
+func (b *BoltStore) GetAllowStale(key CacheKey, allowStale bool) (CacheValue, bool, bool) {
+	var entry boltEntry
+	found := false
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket([]byte(boltDataBucket)).Get(encodeBoltKey(key))
+		if raw == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(raw, &entry)
+	})
+	if err != nil || !found {
+		return CacheValue{}, false, false
+	}
+
+	if entry.Expiry.Before(time.Now()) {
+		if !allowStale && !b.staleModeEnabled() {
+			return CacheValue{}, false, false
+		}
+		return entry.Value, true, true
+	}
+	return entry.Value, true, false
+}
+
+// SetStaleMode toggles whether GetAllowStale returns expired entries
+// regardless of the allowStale argument it's called with, mirroring
+// Cache.SetStaleMode so the circuit breaker's self-preservation mode works
+// the same way across backends. Unlike Cache, BoltStore never actively
+// evicts on TTL expiry — entries are only removed by capacity pressure or
+// Delete — so there is no background eviction to suppress here.
+func (b *BoltStore) SetStaleMode(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&b.staleMode, v)
+}
+
+// staleModeEnabled reports the current value set by SetStaleMode.
+func (b *BoltStore) staleModeEnabled() bool {
+	return atomic.LoadInt32(&b.staleMode) == 1
+}
+
+// SetWithTTL writes key/value to both buckets and evicts the lowest
+// priority entries, via heap.Pop on the in-memory queue, until the store is
+// back under maxSize.
+func (b *BoltStore) SetWithTTL(key CacheKey, value CacheValue, ttl time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	entry := boltEntry{Value: value, Expiry: now.Add(ttl), Timestamp: now}
+
+	if existing, found := b.indexOf[key]; found {
+		heap.Remove(&b.pq, existing.index)
+		b.currSize--
+	}
+
+	item := &CacheItem{key: key, value: value, expiry: entry.Expiry, timestamp: entry.Timestamp}
+	heap.Push(&b.pq, item)
+	b.indexOf[key] = item
+	b.currSize++
+
+	var evicted []CacheKey
+	for b.currSize > b.maxSize {
+		victim := heap.Pop(&b.pq).(*CacheItem)
+		delete(b.indexOf, victim.key)
+		b.currSize--
+		evicted = append(evicted, victim.key)
+	}
+
+	_ = b.db.Update(func(tx *bbolt.Tx) error {
+		data := tx.Bucket([]byte(boltDataBucket))
+		meta := tx.Bucket([]byte(boltMetaBucket))
+
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if err := data.Put(encodeBoltKey(key), encoded); err != nil {
+			return err
+		}
+		if err := meta.Put(encodeBoltKey(key), encoded); err != nil {
+			return err
+		}
+
+		for _, victimKey := range evicted {
+			if err := data.Delete(encodeBoltKey(victimKey)); err != nil {
+				return err
+			}
+			if err := meta.Delete(encodeBoltKey(victimKey)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete removes key from both buckets and the in-memory priority queue.
+func (b *BoltStore) Delete(key CacheKey) {
+	b.mu.Lock()
+	if item, found := b.indexOf[key]; found {
+		heap.Remove(&b.pq, item.index)
+		delete(b.indexOf, key)
+		b.currSize--
+	}
+	b.mu.Unlock()
+
+	_ = b.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket([]byte(boltDataBucket)).Delete(encodeBoltKey(key)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(boltMetaBucket)).Delete(encodeBoltKey(key))
+	})
+}
+
+// Len reports the number of entries currently tracked.
+func (b *BoltStore) Len() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.currSize
+}
+
+// Close releases the underlying BoltDB file handle.
+func (b *BoltStore) Close() error {
+	return b.db.Close()
+}
+
+// encodeBoltKey renders a CacheKey as a bucket key. InventoryID is assumed
+// not to contain boltKeySep, which holds for the domain-style identifiers
+// ("nytimes.com") this service deals with.
+func encodeBoltKey(key CacheKey) []byte {
+	return []byte(key.InventoryID + boltKeySep + key.UtcDatetime)
+}
+
+// decodeBoltKey is the inverse of encodeBoltKey.
+func decodeBoltKey(raw string) (CacheKey, error) {
+	parts := strings.SplitN(raw, boltKeySep, 2)
+	if len(parts) != 2 {
+		return CacheKey{}, fmt.Errorf("malformed bolt key %q", raw)
+	}
+	return CacheKey{InventoryID: parts[0], UtcDatetime: parts[1]}, nil
+}
+
+var _ StaleStore = (*BoltStore)(nil)
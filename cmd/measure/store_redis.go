@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	redisDataPrefix = "scope3:cache:data:"
+	redisZSetKey    = "scope3:cache:priority"
+	// priorityScale spaces out priority buckets so that, within the ZSET's
+	// score ordering, priority always dominates the timestamp tie-break.
+	// It comfortably exceeds any unix-millis timestamp for centuries to come,
+	// so the tie-break term never bleeds into the neighboring bucket.
+	priorityScale = 1e13
+	// maxPriority is the upper bound enforced on Inventory.Priority by its
+	// "max=10" binding tag. ZPOPMIN removes the smallest score first, but
+	// PriorityQueue.Less (and every other Store) evicts the *highest*
+	// numbered priority first, so the score inverts around this bound.
+	maxPriority = 10
+)
+
+// redisEntry is the JSON payload stored for each cache entry's data key.
+type redisEntry struct {
+	Value CacheValue `json:"value"`
+}
+
+// RedisStore is a Store backed by Redis, letting multiple Server instances
+// share one cache instead of each keeping its own in-process copy. TTL
+// expiry is delegated to Redis's native per-key expiration, which means
+// RedisStore does not implement StaleStore: once an entry's TTL elapses,
+// Redis has already reclaimed it, so there is nothing left to serve stale.
+// The priority-based eviction policy is preserved with a ZSET keyed by
+// (maxPriority-priority)*priorityScale + timestamp, so ZPOPMIN removes the
+// highest-numbered priority first (oldest within a tie), matching
+// PriorityQueue.Less, and pops that member when maxSize is exceeded.
+type RedisStore struct {
+	client  *redis.Client
+	maxSize int64
+}
+
+// NewRedisStore creates a RedisStore connected to addr.
+func NewRedisStore(addr string, maxSize int64) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", addr, err)
+	}
+
+	return &RedisStore{client: client, maxSize: maxSize}, nil
+}
+
+// Get retrieves a value by key. A miss (including an expired entry, which
+// Redis has already deleted) returns found=false.
+func (r *RedisStore) Get(key CacheKey) (CacheValue, bool) {
+	raw, err := r.client.Get(context.Background(), dataKey(key)).Bytes()
+	if err != nil {
+		return CacheValue{}, false
+	}
+
+	var entry redisEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return CacheValue{}, false
+	}
+	return entry.Value, true
+}
+
+// SetWithTTL writes key/value with a native Redis expiration of ttl, adds
+// it to the priority ZSET, and evicts the lowest-priority members down to
+// maxSize with ZPOPMIN.
+func (r *RedisStore) SetWithTTL(key CacheKey, value CacheValue, ttl time.Duration) {
+	ctx := context.Background()
+
+	encoded, err := json.Marshal(redisEntry{Value: value})
+	if err != nil {
+		return
+	}
+
+	member := dataKey(key)
+	score := (float64(maxPriority)-float64(value.Priority))*priorityScale + float64(time.Now().UnixMilli())
+
+	pipe := r.client.TxPipeline()
+	pipe.Set(ctx, member, encoded, ttl)
+	pipe.ZAdd(ctx, redisZSetKey, redis.Z{Score: score, Member: member})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return
+	}
+
+	if count, err := r.client.ZCard(ctx, redisZSetKey).Result(); err == nil {
+		for count > r.maxSize {
+			popped, err := r.client.ZPopMin(ctx, redisZSetKey, 1).Result()
+			if err != nil || len(popped) == 0 {
+				break
+			}
+			victim, ok := popped[0].Member.(string)
+			if ok {
+				r.client.Del(ctx, victim)
+			}
+			count--
+		}
+	}
+}
+
+// Delete removes key from both the data keyspace and the priority ZSET.
+func (r *RedisStore) Delete(key CacheKey) {
+	ctx := context.Background()
+	member := dataKey(key)
+	r.client.Del(ctx, member)
+	r.client.ZRem(ctx, redisZSetKey, member)
+}
+
+// Len reports the number of entries currently tracked in the priority
+// ZSET. It overcounts relative to Get once Redis has expired a data key:
+// the corresponding ZSET member stays until ZPOPMIN pops it on overflow or
+// Delete removes it.
+func (r *RedisStore) Len() int64 {
+	count, err := r.client.ZCard(context.Background(), redisZSetKey).Result()
+	if err != nil {
+		return 0
+	}
+	return count
+}
+
+// dataKey renders a CacheKey as the Redis key holding its value.
+func dataKey(key CacheKey) string {
+	return redisDataPrefix + key.InventoryID + "|" + key.UtcDatetime
+}
+
+var _ Store = (*RedisStore)(nil)
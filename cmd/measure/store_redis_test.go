@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// newTestRedisStore connects to REDIS_ADDR (default localhost:6379) and
+// skips the test if nothing is listening there, since this is an
+// integration test against a real Redis rather than an in-process fake.
+func newTestRedisStore(t *testing.T, maxSize int64) *RedisStore {
+	t.Helper()
+
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	conn, err := net.DialTimeout("tcp", addr, 200*time.Millisecond)
+	if err != nil {
+		t.Skipf("no redis reachable at %s, skipping: %v", addr, err)
+	}
+	conn.Close()
+
+	store, err := NewRedisStore(addr, maxSize)
+	if err != nil {
+		t.Fatalf("failed to create RedisStore: %v", err)
+	}
+	t.Cleanup(func() {
+		store.client.FlushDB(context.Background())
+	})
+	return store
+}
+
+// TestRedisStoreEvictionPriority mirrors TestEmissionHandlerEvictionPriority:
+// when over maxSize, the higher-numbered (less important) priority entry
+// must be evicted first, not the lower-numbered one.
+func TestRedisStoreEvictionPriority(t *testing.T) {
+	store := newTestRedisStore(t, 2)
+
+	lowPriority := CacheKey{InventoryID: "nytimes.com", UtcDatetime: "2024-12-30"}
+	highPriority := CacheKey{InventoryID: "yahoo.com", UtcDatetime: "2024-12-30"}
+	thirdEntry := CacheKey{InventoryID: "theguardian.com", UtcDatetime: "2024-12-30"}
+
+	store.SetWithTTL(lowPriority, CacheValue{Emissions: 1, Priority: 1}, time.Hour)
+	store.SetWithTTL(highPriority, CacheValue{Emissions: 2, Priority: 2}, time.Hour)
+	store.SetWithTTL(thirdEntry, CacheValue{Emissions: 3, Priority: 1}, time.Hour)
+
+	if _, ok := store.Get(lowPriority); !ok {
+		t.Errorf("expected %v to survive eviction", lowPriority)
+	}
+	if _, ok := store.Get(highPriority); ok {
+		t.Errorf("expected %v to be evicted", highPriority)
+	}
+	if _, ok := store.Get(thirdEntry); !ok {
+		t.Errorf("expected %v to survive eviction", thirdEntry)
+	}
+}
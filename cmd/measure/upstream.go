@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// chunkRows splits rows into groups of at most size rows each (the final
+// group may be smaller). A non-positive size is treated as "no chunking".
+func chunkRows(rows map[string]Inventory, size int) []map[string]Inventory {
+	if size <= 0 || size >= len(rows) {
+		if len(rows) == 0 {
+			return nil
+		}
+		return []map[string]Inventory{rows}
+	}
+
+	var chunks []map[string]Inventory
+	chunk := make(map[string]Inventory, size)
+	for id, row := range rows {
+		chunk[id] = row
+		if len(chunk) == size {
+			chunks = append(chunks, chunk)
+			chunk = make(map[string]Inventory, size)
+		}
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// fetchEmissionDataChunked splits rows into chunks of at most
+// Config.MaxRowsPerUpstreamCall rows and fetches them concurrently through a
+// pool bounded to Config.MaxConcurrentUpstreamCalls, so a single large batch
+// doesn't monopolize the outbound rate limit or block behind one slow call.
+// While the breaker is HalfOpen, that pool is collapsed to a single slot
+// instead: Breaker.Allow() only lets one request through per probe, but
+// without this a single admitted request could still fan its chunks out as a
+// burst of concurrent calls against a barely-recovering upstream.
+//
+// If partial is false, the first chunk to fail cancels every other in-flight
+// chunk and its error is returned, matching the old single-call, all-or-
+// nothing behavior. If partial is true, a failed chunk's rows are instead
+// reported in failed (keyed by InventoryID) and succeeded only contains rows
+// that actually came back from Scope3 — callers must not cache or otherwise
+// treat a row in failed as a successful lookup.
+func (s *Server) fetchEmissionDataChunked(ctx context.Context, rows map[string]Inventory, partial bool) (succeeded []EmissionData, failed map[string]error, err error) {
+	chunks := chunkRows(rows, s.MaxRowsPerUpstreamCall())
+	if len(chunks) == 0 {
+		return nil, nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	concurrency := s.MaxConcurrentUpstreamCalls()
+	if s.Breaker.State() == BreakerHalfOpen {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, maxInt(1, concurrency))
+	var wg sync.WaitGroup
+	chunkResults := make([][]EmissionData, len(chunks))
+	chunkErrs := make([]error, len(chunks))
+	var firstErr error
+	var firstErrOnce sync.Once
+
+	for i, chunk := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, chunk map[string]Inventory) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			start := time.Now()
+			data, fetchErr := s.getEmissionDataFromInternalAPI(ctx, chunk)
+			s.Logger.Info().Msgf("upstream chunk %d/%d (%d rows) took %s, err=%v", i+1, len(chunks), len(chunk), time.Since(start), fetchErr)
+
+			chunkResults[i] = data
+			chunkErrs[i] = fetchErr
+			if fetchErr != nil && !partial {
+				firstErrOnce.Do(func() { firstErr = fetchErr })
+				cancel()
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	if !partial {
+		if firstErr != nil {
+			return nil, nil, firstErr
+		}
+		for _, data := range chunkResults {
+			succeeded = append(succeeded, data...)
+		}
+		return succeeded, nil, nil
+	}
+
+	failed = make(map[string]error)
+	for i, chunkErr := range chunkErrs {
+		if chunkErr != nil {
+			for id := range chunks[i] {
+				failed[id] = chunkErr
+			}
+			continue
+		}
+		succeeded = append(succeeded, chunkResults[i]...)
+	}
+	return succeeded, failed, nil
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
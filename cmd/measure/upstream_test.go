@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEmissionHandlerChunksLargeBatches configures a small
+// MaxRowsPerUpstreamCall and asserts a batch larger than it is split across
+// multiple upstream calls whose rows, together, cover every requested row.
+func TestEmissionHandlerChunksLargeBatches(t *testing.T) {
+	var mu sync.Mutex
+	var callSizes []int
+	upstream := fakeScope3(t, 10*time.Millisecond, func(ids []string) {
+		mu.Lock()
+		callSizes = append(callSizes, len(ids))
+		mu.Unlock()
+	})
+	defer upstream.Close()
+
+	server, err := CreateServer(1<<30, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	server.BaseURL = upstream.URL
+	server.config.MaxRowsPerUpstreamCall = 2
+	server.config.MaxConcurrentUpstreamCalls = 2
+
+	r := SetUpRouter()
+	r.POST("/", server.EmissionHandler)
+
+	payload := []byte(`{"rows": [
+		{"inventoryId": "a.com", "utcDatetime": "2024-12-30", "priority": 1},
+		{"inventoryId": "b.com", "utcDatetime": "2024-12-30", "priority": 1},
+		{"inventoryId": "c.com", "utcDatetime": "2024-12-30", "priority": 1},
+		{"inventoryId": "d.com", "utcDatetime": "2024-12-30", "priority": 1},
+		{"inventoryId": "e.com", "utcDatetime": "2024-12-30", "priority": 1}
+	]}`)
+	w := postMeasure(r, payload)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status OK, got %v: %s", w.Code, w.Body.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(callSizes) != 3 {
+		t.Fatalf("expected 3 upstream calls (2+2+1 rows), got %d: %v", len(callSizes), callSizes)
+	}
+	var total int
+	for _, size := range callSizes {
+		if size > 2 {
+			t.Errorf("chunk exceeded MaxRowsPerUpstreamCall=2: got %d rows", size)
+		}
+		total += size
+	}
+	if total != 5 {
+		t.Errorf("expected 5 rows across all chunks, got %d", total)
+	}
+}
+
+// TestFetchEmissionDataChunkedCapsConcurrencyWhileHalfOpen ensures chunks are
+// fetched one at a time, despite a generous MaxConcurrentUpstreamCalls, while
+// the breaker is HalfOpen — otherwise a single admitted probe request could
+// still fan its chunks out as a burst of concurrent calls against a
+// barely-recovering upstream.
+func TestFetchEmissionDataChunkedCapsConcurrencyWhileHalfOpen(t *testing.T) {
+	var mu sync.Mutex
+	var concurrent, maxConcurrent int
+	upstream := fakeScope3(t, 0, func(ids []string) {
+		mu.Lock()
+		concurrent++
+		if concurrent > maxConcurrent {
+			maxConcurrent = concurrent
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond) // hold the call open so overlapping calls are counted
+
+		mu.Lock()
+		concurrent--
+		mu.Unlock()
+	})
+	defer upstream.Close()
+
+	server, err := CreateServer(1<<30, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	server.BaseURL = upstream.URL
+	server.config.MaxRowsPerUpstreamCall = 1
+	server.config.MaxConcurrentUpstreamCalls = 5
+	server.Breaker = NewCircuitBreaker(BreakerConfig{
+		Window:         time.Minute,
+		MinRequests:    1,
+		ErrorThreshold: 0.5,
+		OpenDuration:   10 * time.Millisecond,
+	}, nil)
+	server.Breaker.RecordResult(false, time.Millisecond) // trip it open
+	time.Sleep(15 * time.Millisecond)
+	if !server.Breaker.Allow() {
+		t.Fatalf("expected Allow to admit a probe once OpenDuration elapsed")
+	}
+	if server.Breaker.State() != BreakerHalfOpen {
+		t.Fatalf("expected breaker to be HalfOpen, got %s", server.Breaker.State())
+	}
+
+	rows := map[string]Inventory{
+		"a.com": {InventoryID: "a.com", UtcDatetime: "2024-12-30", Priority: 1},
+		"b.com": {InventoryID: "b.com", UtcDatetime: "2024-12-30", Priority: 1},
+		"c.com": {InventoryID: "c.com", UtcDatetime: "2024-12-30", Priority: 1},
+	}
+	if _, _, err := server.fetchEmissionDataChunked(context.Background(), rows, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxConcurrent != 1 {
+		t.Errorf("expected at most 1 concurrent upstream call while HalfOpen, saw %d", maxConcurrent)
+	}
+}
+
+// fakeScope3FailingFor starts an httptest.Server that returns HTTP 500 for
+// any chunk containing failID and otherwise echoes back TotalEmissions like
+// fakeScope3.
+func fakeScope3FailingFor(t *testing.T, failID string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Rows []upstreamRow `json:"rows"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode upstream request: %v", err)
+		}
+
+		for _, row := range body.Rows {
+			if row.RowIdentifier == failID {
+				http.Error(w, "simulated upstream failure", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		var resp struct {
+			Rows []upstreamRow `json:"rows"`
+		}
+		for _, row := range body.Rows {
+			resp.Rows = append(resp.Rows, upstreamRow{RowIdentifier: row.RowIdentifier, TotalEmissions: 1.23})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+// TestEmissionHandlerPartialResults configures one row per chunk, fails the
+// chunk for inventoryId "bad.com", and asserts ?partial=true still returns
+// 200 with every other row's emissions plus an error field for bad.com,
+// while omitting ?partial=true fails the whole request.
+func TestEmissionHandlerPartialResults(t *testing.T) {
+	upstream := fakeScope3FailingFor(t, "bad.com")
+	defer upstream.Close()
+
+	newServer := func(t *testing.T) *Server {
+		server, err := CreateServer(1<<30, 24*time.Hour)
+		if err != nil {
+			t.Fatalf("failed to create server: %v", err)
+		}
+		server.BaseURL = upstream.URL
+		server.config.MaxRowsPerUpstreamCall = 1
+		server.config.MaxConcurrentUpstreamCalls = 2
+		return server
+	}
+
+	payload := []byte(`{"rows": [
+		{"inventoryId": "good.com", "utcDatetime": "2024-12-30", "priority": 1},
+		{"inventoryId": "bad.com", "utcDatetime": "2024-12-30", "priority": 1}
+	]}`)
+
+	t.Run("without partial", func(t *testing.T) {
+		server := newServer(t)
+		r := SetUpRouter()
+		r.POST("/", server.EmissionHandler)
+
+		w := postMeasure(r, payload)
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("expected status 500 without ?partial=true, got %v: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("with partial", func(t *testing.T) {
+		server := newServer(t)
+		r := SetUpRouter()
+		r.POST("/", server.EmissionHandler)
+
+		req, _ := http.NewRequest("POST", "/?partial=true", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status OK with ?partial=true, got %v: %s", w.Code, w.Body.String())
+		}
+
+		var resp struct {
+			Rows []EmissionData `json:"rows"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		byID := make(map[string]EmissionData, len(resp.Rows))
+		for _, row := range resp.Rows {
+			byID[row.InventoryID] = row
+		}
+
+		if got, ok := byID["good.com"]; !ok || got.Error != "" {
+			t.Errorf("expected good.com to succeed with no error, got %+v", got)
+		}
+		if got, ok := byID["bad.com"]; !ok || got.Error == "" {
+			t.Errorf("expected bad.com to carry a row-level error, got %+v", got)
+		}
+
+		if _, found := server.Cache.Get(CacheKey{InventoryID: "bad.com", UtcDatetime: "2024-12-30"}); found {
+			t.Error("a failed row must not be cached")
+		}
+		if _, found := server.Cache.Get(CacheKey{InventoryID: "good.com", UtcDatetime: "2024-12-30"}); !found {
+			t.Error("expected good.com to be cached")
+		}
+	})
+}
+
+// fakeScope3FailingForSlowly behaves like fakeScope3FailingFor but sleeps
+// delay before responding, giving a second concurrent request time to join
+// the first as a singleflight waiter rather than fetching independently.
+func fakeScope3FailingForSlowly(t *testing.T, failID string, delay time.Duration) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Rows []upstreamRow `json:"rows"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode upstream request: %v", err)
+		}
+
+		time.Sleep(delay)
+
+		for _, row := range body.Rows {
+			if row.RowIdentifier == failID {
+				http.Error(w, "simulated upstream failure", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		var resp struct {
+			Rows []upstreamRow `json:"rows"`
+		}
+		for _, row := range body.Rows {
+			resp.Rows = append(resp.Rows, upstreamRow{RowIdentifier: row.RowIdentifier, TotalEmissions: 1.23})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+// TestEmissionHandlerPartialResultsSingleflightWaiter fires a request for
+// bad.com, lets it register as the singleflight fetcher, then fires a second
+// request for the same row so it waits on the first via singleflight.dedupe.
+// It asserts bad.com is not cached afterwards: the waiter reports bad.com's
+// row-level error instead of caching a zero-value success, the poisoning bug
+// where done() ignored the failed map.
+func TestEmissionHandlerPartialResultsSingleflightWaiter(t *testing.T) {
+	upstream := fakeScope3FailingForSlowly(t, "bad.com", 50*time.Millisecond)
+	defer upstream.Close()
+
+	server, err := CreateServer(1<<30, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	server.BaseURL = upstream.URL
+	server.config.MaxRowsPerUpstreamCall = 1
+	server.config.MaxConcurrentUpstreamCalls = 2
+
+	r := SetUpRouter()
+	r.POST("/", server.EmissionHandler)
+
+	payload := []byte(`{"rows": [{"inventoryId": "bad.com", "utcDatetime": "2024-12-30", "priority": 1}]}`)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		req, _ := http.NewRequest("POST", "/?partial=true", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}()
+
+	time.Sleep(10 * time.Millisecond) // let request A register as the fetcher before B arrives
+
+	go func() {
+		defer wg.Done()
+		req, _ := http.NewRequest("POST", "/?partial=true", bytes.NewBuffer(payload))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+	}()
+
+	wg.Wait()
+
+	if _, found := server.Cache.Get(CacheKey{InventoryID: "bad.com", UtcDatetime: "2024-12-30"}); found {
+		t.Error("a failed row must not be cached for a singleflight waiter")
+	}
+}